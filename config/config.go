@@ -0,0 +1,153 @@
+// Package config은 etcd에 저장된 라우팅 규칙을 읽어와 핫 리로드되는 라우팅 테이블을 제공한다.
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// RoutesKey: 라우팅 규칙이 저장되는 etcd 키
+const RoutesKey = "/phantomasync/routes"
+
+// Rule: 경로/메서드별로 토픽과 업스트림을 결정하는 라우팅 규칙
+type Rule struct {
+	PathPrefix         string `json:"pathPrefix"`
+	Method             string `json:"method"`
+	Topic              string `json:"topic"`
+	PartitionKeyHeader string `json:"partitionKeyHeader"`
+	Upstream           string `json:"upstream"`
+}
+
+// Table: 현재 활성화된 라우팅 규칙의 스냅샷. 여러 요청 고루틴이 동시에 읽으므로 RWMutex로 보호한다.
+type Table struct {
+	mu    sync.RWMutex
+	rules []Rule
+}
+
+// NewTable: 빈 테이블 생성
+func NewTable() *Table {
+	return &Table{}
+}
+
+// set: 규칙 전체를 교체
+func (t *Table) set(rules []Rule) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rules = rules
+}
+
+// Match: 가장 먼저 일치하는 규칙을 반환. path는 경로 prefix로, method는 빈 문자열이면 모든 메서드와 매치한다.
+func (t *Table) Match(method, path string) (Rule, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	for _, rule := range t.rules {
+		if rule.Method != "" && rule.Method != method {
+			continue
+		}
+		if len(path) >= len(rule.PathPrefix) && path[:len(rule.PathPrefix)] == rule.PathPrefix {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// Watcher: etcd Watch 이벤트를 구독하여 Table을 최신 상태로 유지한다.
+type Watcher struct {
+	client       *clientv3.Client
+	key          string
+	table        *Table
+	fallbackPath string
+}
+
+// NewWatcher: etcd에 연결해 초기 라우팅 규칙을 읽는다. etcd에 연결할 수 없으면 fallbackPath의 부트스트랩 파일로 대체한다.
+func NewWatcher(endpoints []string, key string, fallbackPath string) (*Watcher, error) {
+	w := &Watcher{key: key, table: NewTable(), fallbackPath: fallbackPath}
+
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   endpoints,
+		DialTimeout: 2 * time.Second,
+	})
+	if err != nil {
+		slog.Warn("etcd unavailable, falling back to bootstrap file", slog.String("error", err.Error()))
+		return w, w.loadFallback()
+	}
+	w.client = client
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	resp, err := client.Get(ctx, key)
+	if err != nil || len(resp.Kvs) == 0 {
+		slog.Warn("could not read routes from etcd, falling back to bootstrap file", slog.String("key", key))
+		return w, w.loadFallback()
+	}
+
+	rules, err := decodeRules(resp.Kvs[0].Value)
+	if err != nil {
+		return w, err
+	}
+	w.table.set(rules)
+	return w, nil
+}
+
+// loadFallback: etcd가 없을 때 사용할 부트스트랩 라우팅 파일을 읽는다.
+func (w *Watcher) loadFallback() error {
+	if w.fallbackPath == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(w.fallbackPath)
+	if err != nil {
+		return err
+	}
+	rules, err := decodeRules(data)
+	if err != nil {
+		return err
+	}
+	w.table.set(rules)
+	return nil
+}
+
+// Table: 현재 라우팅 테이블에 대한 핸들
+func (w *Watcher) Table() *Table {
+	return w.table
+}
+
+// Run: etcd Watch 채널을 구독하며, 이벤트가 올 때마다 라우팅 테이블을 재구성한다. etcd 클라이언트가 없으면 즉시 반환한다.
+func (w *Watcher) Run(ctx context.Context) error {
+	if w.client == nil {
+		return nil
+	}
+
+	watchChan := w.client.Watch(ctx, w.key)
+	for resp := range watchChan {
+		if err := resp.Err(); err != nil {
+			slog.Error("etcd watch error", slog.String("error", err.Error()))
+			continue
+		}
+		for _, event := range resp.Events {
+			rules, err := decodeRules(event.Kv.Value)
+			if err != nil {
+				slog.Error("failed to decode routes from etcd", slog.String("error", err.Error()))
+				continue
+			}
+			w.table.set(rules)
+			slog.Info("reloaded routing table from etcd", slog.Int("rules", len(rules)))
+		}
+	}
+	return ctx.Err()
+}
+
+func decodeRules(data []byte) ([]Rule, error) {
+	var rules []Rule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
@@ -0,0 +1,34 @@
+package config
+
+import "testing"
+
+func TestTableMatch(t *testing.T) {
+	table := NewTable()
+	table.set([]Rule{
+		{PathPrefix: "/orders", Method: "POST", Topic: "orders", Upstream: "http://orders.internal"},
+		{PathPrefix: "/orders", Topic: "orders-read", Upstream: "http://orders-ro.internal"},
+		{PathPrefix: "/", Topic: "catch-all", Upstream: "http://default.internal"},
+	})
+
+	rule, ok := table.Match("POST", "/orders/123")
+	if !ok || rule.Topic != "orders" {
+		t.Fatalf("expected method-specific rule to match, got %+v (ok=%v)", rule, ok)
+	}
+
+	rule, ok = table.Match("GET", "/orders/123")
+	if !ok || rule.Topic != "orders-read" {
+		t.Fatalf("expected method-agnostic rule to match GET, got %+v (ok=%v)", rule, ok)
+	}
+
+	rule, ok = table.Match("GET", "/accounts/1")
+	if !ok || rule.Topic != "catch-all" {
+		t.Fatalf("expected catch-all rule to match unrelated path, got %+v (ok=%v)", rule, ok)
+	}
+}
+
+func TestTableMatchNoRules(t *testing.T) {
+	table := NewTable()
+	if _, ok := table.Match("GET", "/orders"); ok {
+		t.Fatal("expected no match against an empty table")
+	}
+}
@@ -0,0 +1,22 @@
+// Package dedup은 Idempotency-Key로 중복 제출된 요청을 짧은 TTL 동안 걸러내기 위한 저장소를 제공한다.
+package dedup
+
+import (
+	"context"
+	"time"
+)
+
+// CachedResponse: 중복 요청이 들어왔을 때 다시 내려줄, 최초 처리 결과의 스냅샷
+type CachedResponse struct {
+	Status  int                 `json:"status"`
+	Headers map[string][]string `json:"headers"`
+	Body    string              `json:"body"`
+}
+
+// Store: idempotency key와 최초 응답을 짝지어 TTL 동안 보관하는 저장소
+type Store interface {
+	// Get: key에 대한 캐시된 응답을 반환한다. 없으면 ok가 false.
+	Get(ctx context.Context, key string) (response CachedResponse, ok bool, err error)
+	// Set: key에 대한 응답을 ttl 동안 보관한다.
+	Set(ctx context.Context, key string, response CachedResponse, ttl time.Duration) error
+}
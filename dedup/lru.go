@@ -0,0 +1,75 @@
+package dedup
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+type lruEntry struct {
+	key       string
+	response  CachedResponse
+	expiresAt time.Time
+}
+
+// LRUStore: 프로세스 메모리에 유지되는 고정 크기 LRU 캐시. Redis가 없는 단일 인스턴스 배포에 적합하다.
+type LRUStore struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List               // 앞쪽이 가장 최근에 쓰인 항목
+	entries  map[string]*list.Element // key -> *list.Element(*lruEntry)
+}
+
+// NewLRUStore: 용량이 capacity인 LRUStore 생성
+func NewLRUStore(capacity int) *LRUStore {
+	return &LRUStore{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+func (s *LRUStore) Get(_ context.Context, key string) (CachedResponse, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.entries[key]
+	if !ok {
+		return CachedResponse{}, false, nil
+	}
+	entry := elem.Value.(*lruEntry)
+	if time.Now().After(entry.expiresAt) {
+		s.order.Remove(elem)
+		delete(s.entries, key)
+		return CachedResponse{}, false, nil
+	}
+
+	s.order.MoveToFront(elem)
+	return entry.response, true, nil
+}
+
+func (s *LRUStore) Set(_ context.Context, key string, response CachedResponse, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.entries[key]; ok {
+		elem.Value.(*lruEntry).response = response
+		elem.Value.(*lruEntry).expiresAt = time.Now().Add(ttl)
+		s.order.MoveToFront(elem)
+		return nil
+	}
+
+	entry := &lruEntry{key: key, response: response, expiresAt: time.Now().Add(ttl)}
+	elem := s.order.PushFront(entry)
+	s.entries[key] = elem
+
+	if s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		if oldest != nil {
+			s.order.Remove(oldest)
+			delete(s.entries, oldest.Value.(*lruEntry).key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package dedup
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLRUStoreGetSet(t *testing.T) {
+	store := NewLRUStore(2)
+	ctx := context.Background()
+
+	if _, ok, err := store.Get(ctx, "missing"); err != nil || ok {
+		t.Fatalf("expected no entry for missing key, got ok=%v err=%v", ok, err)
+	}
+
+	want := CachedResponse{Status: 200, Body: "hello"}
+	if err := store.Set(ctx, "a", want, time.Minute); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+
+	got, ok, err := store.Get(ctx, "a")
+	if err != nil || !ok || !reflect.DeepEqual(got, want) {
+		t.Fatalf("Get(%q) = %+v, ok=%v, err=%v; want %+v", "a", got, ok, err, want)
+	}
+}
+
+func TestLRUStoreExpires(t *testing.T) {
+	store := NewLRUStore(2)
+	ctx := context.Background()
+
+	if err := store.Set(ctx, "a", CachedResponse{Status: 200}, time.Millisecond); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	if _, ok, err := store.Get(ctx, "a"); err != nil || ok {
+		t.Fatalf("expected entry to have expired, got ok=%v err=%v", ok, err)
+	}
+}
+
+func TestLRUStoreEvictsOldest(t *testing.T) {
+	store := NewLRUStore(2)
+	ctx := context.Background()
+
+	store.Set(ctx, "a", CachedResponse{Status: 1}, time.Minute)
+	store.Set(ctx, "b", CachedResponse{Status: 2}, time.Minute)
+	store.Set(ctx, "c", CachedResponse{Status: 3}, time.Minute)
+
+	if _, ok, _ := store.Get(ctx, "a"); ok {
+		t.Fatal("expected oldest entry \"a\" to have been evicted once capacity was exceeded")
+	}
+	if _, ok, _ := store.Get(ctx, "c"); !ok {
+		t.Fatal("expected most recently inserted entry \"c\" to still be present")
+	}
+}
@@ -0,0 +1,44 @@
+package dedup
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore: 여러 producer 인스턴스가 idempotency 캐시를 공유해야 할 때 쓰는 Redis 기반 구현
+type RedisStore struct {
+	Client    *redis.Client
+	KeyPrefix string
+}
+
+// NewRedisStore: RedisStore 생성. keyPrefix는 다른 용도로 쓰는 Redis 키와 충돌하지 않게 구분하는 네임스페이스다.
+func NewRedisStore(client *redis.Client, keyPrefix string) *RedisStore {
+	return &RedisStore{Client: client, KeyPrefix: keyPrefix}
+}
+
+func (s *RedisStore) Get(ctx context.Context, key string) (CachedResponse, bool, error) {
+	data, err := s.Client.Get(ctx, s.KeyPrefix+key).Bytes()
+	if err == redis.Nil {
+		return CachedResponse{}, false, nil
+	}
+	if err != nil {
+		return CachedResponse{}, false, err
+	}
+
+	var response CachedResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return CachedResponse{}, false, err
+	}
+	return response, true, nil
+}
+
+func (s *RedisStore) Set(ctx context.Context, key string, response CachedResponse, ttl time.Duration) error {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	return s.Client.Set(ctx, s.KeyPrefix+key, data, ttl).Err()
+}
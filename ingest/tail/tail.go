@@ -0,0 +1,206 @@
+// Package tail은 파일 기반 로그를 추적(tail)하여 동일한 kgo.Client로 Kafka에 적재하는 ingest 모드를 제공한다.
+package tail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nxadm/tail"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// FileMapping: 파일별로 어느 토픽에 적재할지 지정
+type FileMapping struct {
+	Path  string
+	Topic string
+}
+
+// Record: Kafka에 적재되는 한 줄 분량의 구조화된 로그 레코드
+type Record struct {
+	Source    string    `json:"source"`
+	Path      string    `json:"path"`
+	Timestamp time.Time `json:"timestamp"`
+	Line      string    `json:"line"`
+}
+
+// queuedRecord: 내부 채널에서만 쓰이는, produce 성공 시 오프셋을 갱신하기 위한 래퍼
+type queuedRecord struct {
+	record Record
+	topic  string
+	offset int64
+}
+
+// Tailer: 여러 파일을 추적하여 각 파일에 매핑된 토픽으로 전달하는 ingest 러너
+type Tailer struct {
+	KafkaClient *kgo.Client
+	Mappings    []FileMapping
+	OffsetDir   string
+
+	queue chan queuedRecord
+}
+
+// NewTailer: Tailer 생성. queueSize는 tailer 고루틴과 producer 고루틴 사이의 버퍼 크기(백프레셔)
+func NewTailer(client *kgo.Client, mappings []FileMapping, offsetDir string, queueSize int) *Tailer {
+	return &Tailer{
+		KafkaClient: client,
+		Mappings:    mappings,
+		OffsetDir:   offsetDir,
+		queue:       make(chan queuedRecord, queueSize),
+	}
+}
+
+// Run: 파일마다 추적 고루틴을 띄우고, 단일 producer 고루틴으로 Kafka에 적재한다.
+func (t *Tailer) Run(ctx context.Context) error {
+	if err := os.MkdirAll(t.OffsetDir, 0o755); err != nil {
+		return err
+	}
+
+	done := make(chan error, len(t.Mappings))
+	for _, mapping := range t.Mappings {
+		mapping := mapping
+		go func() {
+			done <- t.tailFile(ctx, mapping)
+		}()
+	}
+
+	go t.produceLoop(ctx)
+
+	var firstErr error
+	for range t.Mappings {
+		if err := <-done; err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// tailFile: 한 파일을 마지막으로 읽은 위치부터 추적하며 큐에 넣는다(백프레셔가 걸리면 여기서 블록된다).
+func (t *Tailer) tailFile(ctx context.Context, mapping FileMapping) error {
+	offset := t.readOffset(mapping.Path)
+
+	tailed, err := tail.TailFile(mapping.Path, tail.Config{
+		Follow:   true,
+		ReOpen:   true,
+		Location: &tail.SeekInfo{Offset: offset, Whence: 0},
+		Logger:   tail.DiscardingLogger,
+	})
+	if err != nil {
+		return fmt.Errorf("tail %s: %w", mapping.Path, err)
+	}
+	defer tailed.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case line, ok := <-tailed.Lines:
+			if !ok {
+				return nil
+			}
+			if line.Err != nil {
+				slog.Error("error reading log line", slog.String("path", mapping.Path), slog.String("error", line.Err.Error()))
+				continue
+			}
+
+			pos, err := tailed.Tell()
+			if err != nil {
+				slog.Warn("failed to read tail offset", slog.String("path", mapping.Path), slog.String("error", err.Error()))
+			}
+
+			record := Record{Source: "tail", Path: mapping.Path, Timestamp: time.Now(), Line: line.Text}
+			select {
+			case t.queue <- queuedRecord{record: record, topic: mapping.Topic, offset: pos}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+const (
+	produceBaseBackoff = 200 * time.Millisecond
+	produceMaxBackoff  = 5 * time.Second
+)
+
+// produceLoop: 큐에서 레코드를 꺼내 Kafka에 적재하고, 성공한 경우에만 오프셋을 디스크에 남긴다. 한 줄의
+// 적재는 성공할 때까지(또는 ctx가 취소될 때까지) 재시도한다 - tailed.Lines에서 이미 꺼낸 줄은 다시 읽을 수
+// 없으므로, 여기서 포기하고 다음 줄로 넘어가면 그 줄은 영영 사라지고 이후 줄의 오프셋이 먼저 저장되어
+// 재시작 시 건너뛰게 된다.
+func (t *Tailer) produceLoop(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case queued := <-t.queue:
+			t.produceWithRetry(ctx, queued)
+		}
+	}
+}
+
+// produceWithRetry: 한 레코드를 backoff와 함께 재시도하며 적재하고, 성공한 경우에만 오프셋을 저장한다.
+func (t *Tailer) produceWithRetry(ctx context.Context, queued queuedRecord) {
+	jsonData, err := json.Marshal(queued.record)
+	if err != nil {
+		slog.Error("failed to encode tail record", slog.String("error", err.Error()))
+		return
+	}
+	kafkaRecord := &kgo.Record{Topic: queued.topic, Value: jsonData}
+
+	for attempt := 0; ; attempt++ {
+		if err := t.KafkaClient.ProduceSync(ctx, kafkaRecord).FirstErr(); err == nil {
+			break
+		} else {
+			slog.Warn("failed to produce tail record, will retry", slog.String("path", queued.record.Path), slog.Int("attempt", attempt+1), slog.String("error", err.Error()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retryBackoff(attempt)):
+		}
+	}
+
+	t.writeOffset(queued.record.Path, queued.offset)
+}
+
+// retryBackoff: 시도 횟수에 따라 지수적으로 늘어나되 produceMaxBackoff에서 상한이 걸리는 대기 시간
+func retryBackoff(attempt int) time.Duration {
+	if attempt > 6 {
+		attempt = 6
+	}
+	d := produceBaseBackoff * time.Duration(1<<uint(attempt))
+	if d > produceMaxBackoff {
+		d = produceMaxBackoff
+	}
+	return d
+}
+
+// readOffset / writeOffset: 재시작 시 마지막으로 읽은 위치에서 이어서 읽을 수 있도록 오프셋을 디스크에 저장한다.
+func (t *Tailer) offsetFile(path string) string {
+	return filepath.Join(t.OffsetDir, strings.ReplaceAll(path, string(os.PathSeparator), "_")+".offset")
+}
+
+func (t *Tailer) readOffset(path string) int64 {
+	data, err := os.ReadFile(t.offsetFile(path))
+	if err != nil {
+		return 0
+	}
+	offset, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return offset
+}
+
+func (t *Tailer) writeOffset(path string, offset int64) {
+	if err := os.WriteFile(t.offsetFile(path), []byte(strconv.FormatInt(offset, 10)), 0o644); err != nil {
+		slog.Warn("failed to persist tail offset", slog.String("path", path), slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,51 @@
+package tail
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOffsetRoundTrip(t *testing.T) {
+	tailer := &Tailer{OffsetDir: t.TempDir()}
+	path := "/var/log/app.log"
+
+	if got := tailer.readOffset(path); got != 0 {
+		t.Fatalf("expected 0 offset before any write, got %d", got)
+	}
+
+	tailer.writeOffset(path, 1234)
+	if got := tailer.readOffset(path); got != 1234 {
+		t.Fatalf("readOffset() = %d, want 1234", got)
+	}
+
+	tailer.writeOffset(path, 5678)
+	if got := tailer.readOffset(path); got != 5678 {
+		t.Fatalf("readOffset() after second write = %d, want 5678", got)
+	}
+}
+
+func TestOffsetFileNamesDistinctPathsDistinctly(t *testing.T) {
+	tailer := &Tailer{OffsetDir: t.TempDir()}
+	if tailer.offsetFile("/var/log/a.log") == tailer.offsetFile("/var/log/b.log") {
+		t.Fatal("expected distinct paths to map to distinct offset files")
+	}
+}
+
+func TestRetryBackoffCapsAndGrows(t *testing.T) {
+	if got := retryBackoff(0); got != produceBaseBackoff {
+		t.Fatalf("retryBackoff(0) = %v, want %v", got, produceBaseBackoff)
+	}
+
+	prev := time.Duration(0)
+	for attempt := 0; attempt < 6; attempt++ {
+		got := retryBackoff(attempt)
+		if got < prev {
+			t.Fatalf("retryBackoff(%d) = %v, expected it not to shrink from %v", attempt, got, prev)
+		}
+		prev = got
+	}
+
+	if got := retryBackoff(100); got != produceMaxBackoff {
+		t.Fatalf("retryBackoff(100) = %v, want cap %v", got, produceMaxBackoff)
+	}
+}
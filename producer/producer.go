@@ -2,33 +2,68 @@ package main
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"flag"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"os"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/computerphilosopher/phantomasync/config"
+	"github.com/computerphilosopher/phantomasync/dedup"
+	"github.com/computerphilosopher/phantomasync/ingest/tail"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
 	"github.com/twmb/franz-go/pkg/kgo"
 )
 
+// IdempotencyKeyHeader: 재시도 시 동일한 요청임을 표시하는 헤더 (draft-ietf-httpapi-idempotency-key-header)
+const IdempotencyKeyHeader = "Idempotency-Key"
+
 // Server 구조체 정의
 type Server struct {
 	KafkaClient *kgo.Client
+	Routes      *config.Table
+
+	// 동기 요청/응답 모드에서 쓰는 상태. ReplyTopic이 비어있으면 동기 모드는 비활성화된다.
+	ReplyTopic    string
+	ReplyTimeout  time.Duration
+	replyConsumer *kgo.Client
+	replyWaiters  sync.Map // correlationID(string) -> chan ReplyPayload
+
+	// Idempotency-Key 중복 제거. Dedup이 nil이면 중복 제거는 비활성화된다.
+	Dedup    dedup.Store
+	DedupTTL time.Duration
 }
 
 // 요청 데이터를 구조체로 정의
 type RequestPayload struct {
-	Method  string              `json:"method"`
-	URI     string              `json:"uri"`
-	Headers map[string][]string `json:"headers"`
-	Body    string              `json:"body"`
+	Method         string              `json:"method"`
+	URI            string              `json:"uri"`
+	Headers        map[string][]string `json:"headers"`
+	Body           string              `json:"body"`
+	CorrelationID  string              `json:"correlationId,omitempty"`
+	ReplyTopic     string              `json:"replyTopic,omitempty"`
+	IdempotencyKey string              `json:"idempotencyKey,omitempty"`
+	Upstream       string              `json:"upstream,omitempty"`
+}
+
+// ReplyPayload: replayer가 업스트림 응답을 담아 ReplyTopic에 발행하는 레코드
+type ReplyPayload struct {
+	CorrelationID string              `json:"correlationId"`
+	Status        int                 `json:"status"`
+	Headers       map[string][]string `json:"headers"`
+	Body          string              `json:"body"`
 }
 
 // NewServer: 서버 구조체 생성 및 Redis 연결 설정
-func NewServer(seeds []string) (*Server, error) {
+func NewServer(seeds []string, routes *config.Table, replyTopic string, replyTimeout time.Duration, dedupStore dedup.Store, dedupTTL time.Duration) (*Server, error) {
 	client, err := kgo.NewClient(
 		kgo.ConsumerGroup("my-group-identifier"),
 		kgo.ConsumeTopics("foo"),
@@ -37,9 +72,66 @@ func NewServer(seeds []string) (*Server, error) {
 		return nil, err
 	}
 
-	return &Server{
-		KafkaClient: client,
-	}, nil
+	server := &Server{
+		KafkaClient:  client,
+		Routes:       routes,
+		ReplyTopic:   replyTopic,
+		ReplyTimeout: replyTimeout,
+		Dedup:        dedupStore,
+		DedupTTL:     dedupTTL,
+	}
+
+	if replyTopic != "" {
+		// 각 인스턴스는 자신이 발급한 correlation ID를 기다리고 있으므로, 컨슈머 그룹으로 묶어 파티션을
+		// 나눠 받으면 안 되고 모든 인스턴스가 ReplyTopic의 모든 레코드를 직접 봐야 한다.
+		replyConsumer, err := kgo.NewClient(
+			kgo.ConsumeTopics(replyTopic),
+		)
+		if err != nil {
+			return nil, err
+		}
+		server.replyConsumer = replyConsumer
+	}
+
+	return server, nil
+}
+
+// runReplyConsumer: ReplyTopic을 구독하며, correlation ID로 대기 중인 핸들러에게 응답을 전달한다.
+func (s *Server) runReplyConsumer(ctx context.Context) error {
+	if s.replyConsumer == nil {
+		return nil
+	}
+
+	for {
+		fetches := s.replyConsumer.PollFetches(ctx)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		fetches.EachError(func(topic string, partition int32, err error) {
+			slog.Error("reply fetch error", slog.String("topic", topic), slog.Int("partition", int(partition)), slog.String("error", err.Error()))
+		})
+
+		fetches.EachRecord(func(record *kgo.Record) {
+			var reply ReplyPayload
+			if err := json.Unmarshal(record.Value, &reply); err != nil {
+				slog.Error("failed to decode reply record", slog.String("error", err.Error()))
+				return
+			}
+
+			waiter, ok := s.replyWaiters.Load(reply.CorrelationID)
+			if !ok {
+				return
+			}
+			select {
+			case waiter.(chan ReplyPayload) <- reply:
+			default:
+			}
+		})
+
+		if err := s.replyConsumer.CommitUncommittedOffsets(ctx); err != nil {
+			slog.Error("failed to commit reply offsets", slog.String("error", err.Error()))
+		}
+	}
 }
 
 // 요청 바디 읽기 및 RequestPayload 생성
@@ -50,24 +142,51 @@ func (s *Server) parseRequest(c *gin.Context) (RequestPayload, error) {
 	}
 
 	return RequestPayload{
-		Method:  c.Request.Method,
-		URI:     c.Request.RequestURI,
-		Headers: c.Request.Header,
-		Body:    string(body),
+		Method:         c.Request.Method,
+		URI:            c.Request.RequestURI,
+		Headers:        c.Request.Header,
+		Body:           string(body),
+		IdempotencyKey: c.GetHeader(IdempotencyKeyHeader),
 	}, nil
 }
 
 // Redis에 요청 데이터 저장
 func (s *Server) produce(data RequestPayload) error {
+	topic := "foo"
+	var key []byte
+	if s.Routes != nil {
+		if rule, ok := s.Routes.Match(data.Method, data.URI); ok {
+			topic = rule.Topic
+			data.Upstream = rule.Upstream
+			if rule.PartitionKeyHeader != "" {
+				if values, ok := data.Headers[rule.PartitionKeyHeader]; ok && len(values) > 0 {
+					key = partitionKey(values[0])
+				}
+			}
+		}
+	}
+	// idempotency key가 있으면 파티션 해시보다 우선해서 레코드 키로 사용한다.
+	if data.IdempotencyKey != "" {
+		key = []byte(data.IdempotencyKey)
+	}
+
 	jsonData, err := json.Marshal(data)
 	if err != nil {
 		return err
 	}
-	record := &kgo.Record{Topic: "foo", Value: jsonData}
+
+	headers := []kgo.RecordHeader{{Key: "RequestID", Value: []byte(uuid.NewString())}}
+	record := &kgo.Record{Topic: topic, Key: key, Value: jsonData, Headers: headers}
 
 	return s.KafkaClient.ProduceSync(context.Background(), record).FirstErr()
 }
 
+// partitionKey: 헤더 값을 해시하여 파티션 키로 사용할 바이트열을 만든다.
+func partitionKey(headerValue string) []byte {
+	sum := sha256.Sum256([]byte(headerValue))
+	return sum[:8]
+}
+
 // 엔드포인트 핸들러 함수
 func (s *Server) handleRequest(c *gin.Context) {
 	// 요청 데이터 처리
@@ -78,37 +197,170 @@ func (s *Server) handleRequest(c *gin.Context) {
 		return
 	}
 
+	// 동일한 idempotency key로 TTL 내에 이미 처리된 요청이면, 다시 큐에 넣지 않고 원래 응답을 재생한다.
+	if requestData.IdempotencyKey != "" && s.Dedup != nil {
+		if cached, ok, err := s.Dedup.Get(c.Request.Context(), requestData.IdempotencyKey); err != nil {
+			slog.Error("idempotency lookup failed", slog.String("error", err.Error()))
+		} else if ok {
+			slog.Info("replaying cached response for idempotency key", slog.String("idempotencyKey", requestData.IdempotencyKey))
+			writeCachedResponse(c, cached)
+			return
+		}
+	}
+
+	// 호출자가 Prefer: respond-async를 보내면 응답을 기다리지 않는 fire-and-forget 모드로 동작
+	async := c.GetHeader("Prefer") == "respond-async"
+
+	var waiter chan ReplyPayload
+	if !async && s.ReplyTopic != "" {
+		requestData.CorrelationID = uuid.NewString()
+		requestData.ReplyTopic = s.ReplyTopic
+
+		waiter = make(chan ReplyPayload, 1)
+		s.replyWaiters.Store(requestData.CorrelationID, waiter)
+		defer s.replyWaiters.Delete(requestData.CorrelationID)
+	}
+
 	// Redis에 저장
 	if err := s.produce(requestData); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to push to Redis queue"})
 		slog.Error("Failed to push to Redis queue", slog.String("error", err.Error()))
 		return
 	}
-
-	// 성공적으로 Redis에 저장되었음을 응답
 	slog.Info("Request added to Redis queue", slog.String("method", requestData.Method), slog.String("uri", requestData.URI))
-	c.JSON(http.StatusOK, gin.H{"message": "Request added to Redis queue"})
+
+	if waiter == nil {
+		// 동기 응답을 기다릴 수 없는 경우(비동기 요청이거나 동기 모드가 꺼져 있는 경우) 202로 즉시 응답
+		body, _ := json.Marshal(gin.H{"message": "Request added to Redis queue"})
+		s.respondAndCache(c, requestData.IdempotencyKey, dedup.CachedResponse{
+			Status:  http.StatusAccepted,
+			Headers: map[string][]string{"Content-Type": {"application/json"}},
+			Body:    string(body),
+		})
+		return
+	}
+
+	select {
+	case reply := <-waiter:
+		s.respondAndCache(c, requestData.IdempotencyKey, dedup.CachedResponse{
+			Status:  reply.Status,
+			Headers: reply.Headers,
+			Body:    reply.Body,
+		})
+	case <-time.After(s.ReplyTimeout):
+		// 타임아웃은 최종 결과가 아니므로 캐시하지 않는다. 재시도하면 다시 기다릴 수 있다.
+		slog.Warn("timed out waiting for upstream reply", slog.String("correlationId", requestData.CorrelationID))
+		c.JSON(http.StatusGatewayTimeout, gin.H{"error": "timed out waiting for upstream reply"})
+	}
+}
+
+// respondAndCache: 응답을 호출자에게 쓰고, idempotency key가 있으면 TTL 동안 재생할 수 있도록 캐시한다.
+func (s *Server) respondAndCache(c *gin.Context, idempotencyKey string, response dedup.CachedResponse) {
+	if idempotencyKey != "" && s.Dedup != nil {
+		if err := s.Dedup.Set(c.Request.Context(), idempotencyKey, response, s.DedupTTL); err != nil {
+			slog.Error("failed to persist idempotency response", slog.String("error", err.Error()))
+		}
+	}
+	writeCachedResponse(c, response)
+}
+
+// writeCachedResponse: 캐시된(혹은 방금 만든) 응답을 그대로 클라이언트에 내려준다.
+func writeCachedResponse(c *gin.Context, response dedup.CachedResponse) {
+	for header, values := range response.Headers {
+		for _, value := range values {
+			c.Header(header, value)
+		}
+	}
+	c.Data(response.Status, "", []byte(response.Body))
+}
+
+// parseTailMappings: "path:topic,path2:topic2" 형태의 플래그 값을 tail.FileMapping 목록으로 변환
+func parseTailMappings(raw string) ([]tail.FileMapping, error) {
+	var mappings []tail.FileMapping
+	for _, entry := range strings.Split(raw, ",") {
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid tail mapping %q, expected path:topic", entry)
+		}
+		mappings = append(mappings, tail.FileMapping{Path: parts[0], Topic: parts[1]})
+	}
+	return mappings, nil
 }
 
 func main() {
 	// 명령줄 인수 처리
 	kafkaAddrRaw := flag.String("kafka address", "localhost:9092", "kafka server address")
+	etcdAddrRaw := flag.String("etcd address", "localhost:2379", "etcd cluster address")
+	routesFile := flag.String("routes bootstrap", "routes.json", "bootstrap routing rules used when etcd is unavailable")
+	tailFilesRaw := flag.String("tail files", "", "comma-separated path:topic pairs to tail into kafka (disabled if empty)")
+	tailOffsetDir := flag.String("tail offset dir", "tail-offsets", "directory used to persist tail read offsets")
+	tailQueueSize := flag.Int("tail queue size", 1024, "bounded channel size between tailer goroutines and the producer")
+	replyTopic := flag.String("reply topic", "", "kafka topic upstream replies are delivered on (disables synchronous request/reply if empty)")
+	replyTimeout := flag.Duration("reply timeout", 30*time.Second, "how long to block waiting for an upstream reply before returning 504")
+	dedupTTL := flag.Duration("dedup ttl", 5*time.Minute, "how long an Idempotency-Key response is replayed before a new submission is allowed")
+	dedupLRUSize := flag.Int("dedup lru size", 10000, "in-memory dedup cache capacity, used when --redis address is empty")
+	redisAddrRaw := flag.String("redis address", "", "redis address for shared idempotency dedup (falls back to an in-memory LRU if empty)")
 	flag.Parse()
 
 	kafkaAddr := strings.Split(*kafkaAddrRaw, ",")
+	etcdAddr := strings.Split(*etcdAddrRaw, ",")
 
 	// slog 기본 핸들러 설정 (표준 출력)
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	slog.SetDefault(logger)
 
+	// etcd에서 라우팅 규칙을 읽어오고, watch로 변경 사항을 핫 리로드
+	watcher, err := config.NewWatcher(etcdAddr, config.RoutesKey, *routesFile)
+	if err != nil {
+		slog.Error("Failed to initialize routing config", slog.String("error", err.Error()))
+		return
+	}
+	go func() {
+		if err := watcher.Run(context.Background()); err != nil {
+			slog.Error("routing config watcher stopped", slog.String("error", err.Error()))
+		}
+	}()
+
+	// Idempotency-Key 중복 제거 저장소. redis address가 설정되어 있으면 Redis를, 아니면 로컬 LRU를 사용한다.
+	var dedupStore dedup.Store
+	if *redisAddrRaw != "" {
+		dedupStore = dedup.NewRedisStore(redis.NewClient(&redis.Options{Addr: *redisAddrRaw}), "phantomasync:dedup:")
+	} else {
+		dedupStore = dedup.NewLRUStore(*dedupLRUSize)
+	}
+
 	// Server 인스턴스 생성
-	server, err := NewServer(kafkaAddr)
+	server, err := NewServer(kafkaAddr, watcher.Table(), *replyTopic, *replyTimeout, dedupStore, *dedupTTL)
 	if err != nil {
 		slog.Error("Failed to initialize server", slog.String("error", err.Error()))
 		return
 	}
 	slog.Info("Connected to kafka", slog.String("address", *kafkaAddrRaw))
 
+	if *replyTopic != "" {
+		go func() {
+			if err := server.runReplyConsumer(context.Background()); err != nil {
+				slog.Error("reply consumer stopped", slog.String("error", err.Error()))
+			}
+		}()
+	}
+
+	// tail 플래그가 설정된 경우, 파일 기반 로그도 같은 kgo.Client로 적재
+	if *tailFilesRaw != "" {
+		mappings, err := parseTailMappings(*tailFilesRaw)
+		if err != nil {
+			slog.Error("Failed to parse tail files", slog.String("error", err.Error()))
+			return
+		}
+		tailer := tail.NewTailer(server.KafkaClient, mappings, *tailOffsetDir, *tailQueueSize)
+		go func() {
+			if err := tailer.Run(context.Background()); err != nil {
+				slog.Error("tail ingestion stopped", slog.String("error", err.Error()))
+			}
+		}()
+		slog.Info("Tailing log files into kafka", slog.Int("files", len(mappings)))
+	}
+
 	// Gin 라우터 설정
 	r := gin.Default()
 
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/computerphilosopher/phantomasync/dedup"
+	"github.com/gin-gonic/gin"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// dedupTTL: idempotency key로 전달 완료를 표시해 두는 기간. 이 기간 안에 같은 키가 다시 오면 재전송을 건너뛴다.
+const dedupTTL = 5 * time.Minute
+
+// foo.dlq 토픽에 실패한 레코드와 함께 기록되는 메타데이터
+type dlqEnvelope struct {
+	Payload RequestPayload `json:"payload"`
+	Reason  string         `json:"reason"`
+	Attempt int            `json:"attempt"`
+}
+
+// 큐에 쌓인 요청을 구조체로 정의 (producer.RequestPayload와 동일한 와이어 포맷)
+type RequestPayload struct {
+	Method         string              `json:"method"`
+	URI            string              `json:"uri"`
+	Headers        map[string][]string `json:"headers"`
+	Body           string              `json:"body"`
+	CorrelationID  string              `json:"correlationId,omitempty"`
+	ReplyTopic     string              `json:"replyTopic,omitempty"`
+	Upstream       string              `json:"upstream,omitempty"`
+	IdempotencyKey string              `json:"idempotencyKey,omitempty"`
+}
+
+// ReplyPayload: producer.ReplyPayload와 동일한 와이어 포맷. 동기 요청/응답 모드에서 업스트림 응답을 실어 보낸다.
+type ReplyPayload struct {
+	CorrelationID string              `json:"correlationId"`
+	Status        int                 `json:"status"`
+	Headers       map[string][]string `json:"headers"`
+	Body          string              `json:"body"`
+}
+
+const (
+	maxRetries  = 5
+	baseBackoff = 200 * time.Millisecond
+	dlqTopic    = "foo.dlq"
+	sourceTopic = "foo"
+)
+
+// Replayer: 큐에 쌓인 요청을 실제 업스트림으로 재전송하는 컨슈머
+type Replayer struct {
+	KafkaClient  *kgo.Client
+	UpstreamBase string
+	HTTPClient   *http.Client
+	Dedup        dedup.Store
+}
+
+// NewReplayer: Replayer 구조체 생성 및 Kafka 컨슈머 그룹 설정
+func NewReplayer(seeds []string, upstreamBase string) (*Replayer, error) {
+	client, err := kgo.NewClient(
+		kgo.SeedBrokers(seeds...),
+		kgo.ConsumerGroup("my-group-identifier"),
+		kgo.ConsumeTopics(sourceTopic),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Replayer{
+		KafkaClient:  client,
+		UpstreamBase: upstreamBase,
+		HTTPClient:   &http.Client{Timeout: 10 * time.Second},
+		Dedup:        dedup.NewLRUStore(10000),
+	}, nil
+}
+
+// Run: FetchMessage -> Process -> CommitMessages 루프
+func (r *Replayer) Run(ctx context.Context) error {
+	for {
+		fetches := r.KafkaClient.PollFetches(ctx)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		fetches.EachError(func(topic string, partition int32, err error) {
+			slog.Error("fetch error", slog.String("topic", topic), slog.Int("partition", int(partition)), slog.String("error", err.Error()))
+		})
+
+		failed := false
+		fetches.EachRecord(func(record *kgo.Record) {
+			if err := r.processRecord(ctx, record); err != nil {
+				failed = true
+				slog.Error("record neither delivered nor routed to DLQ, will not commit its offset", slog.String("error", err.Error()))
+			}
+		})
+
+		// 레코드가 업스트림으로도, DLQ로도 가지 못했다면 그 레코드의 오프셋을 건너뛰면 안 되므로 이번 배치는 커밋하지 않는다.
+		if failed {
+			continue
+		}
+
+		if err := r.KafkaClient.CommitUncommittedOffsets(ctx); err != nil {
+			slog.Error("failed to commit offsets", slog.String("error", err.Error()))
+		}
+	}
+}
+
+// processRecord: 레코드를 업스트림 요청으로 재구성하고 재시도와 함께 전송
+func (r *Replayer) processRecord(ctx context.Context, record *kgo.Record) error {
+	var payload RequestPayload
+	if err := json.Unmarshal(record.Value, &payload); err != nil {
+		slog.Error("failed to decode queued request", slog.String("error", err.Error()))
+		return nil
+	}
+
+	// idempotency key로 이미 전달한 레코드면 at-least-once 재전달을 건너뛴다. record.Key는 idempotency key가
+	// 없을 때 라우팅 파티션 해시로도 쓰이므로(여러 요청이 같은 해시를 공유할 수 있다), 반드시 payload의
+	// IdempotencyKey만 dedup 기준으로 써야 한다.
+	dedupKey := payload.IdempotencyKey
+	if dedupKey != "" && r.Dedup != nil {
+		if _, delivered, err := r.Dedup.Get(ctx, dedupKey); err == nil && delivered {
+			slog.Info("skipping already-delivered record", slog.String("idempotencyKey", dedupKey))
+			return nil
+		}
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoffWithJitter(attempt))
+		}
+
+		result, err := r.send(ctx, payload)
+		if err != nil {
+			lastErr = err
+			slog.Warn("upstream delivery failed, will retry", slog.Int("attempt", attempt+1), slog.String("error", err.Error()))
+			continue
+		}
+
+		if payload.ReplyTopic != "" && payload.CorrelationID != "" {
+			if err := r.publishReply(ctx, payload, result); err != nil {
+				slog.Error("failed to publish reply", slog.String("error", err.Error()))
+			}
+		}
+
+		if dedupKey != "" && r.Dedup != nil {
+			if err := r.Dedup.Set(ctx, dedupKey, dedup.CachedResponse{Status: result.Status}, dedupTTL); err != nil {
+				slog.Warn("failed to record delivery for dedup", slog.String("error", err.Error()))
+			}
+		}
+		return nil
+	}
+
+	if err := r.publishToDLQ(ctx, payload, lastErr); err != nil {
+		return err
+	}
+	return nil
+}
+
+// upstreamResult: 업스트림 호출 결과. 동기 요청/응답 모드에서 ReplyPayload로 그대로 전달된다.
+type upstreamResult struct {
+	Status  int
+	Headers map[string][]string
+	Body    string
+}
+
+// send: RequestPayload를 업스트림 http.Request로 재구성하여 전송. 라우팅 규칙이 지정한 업스트림이 있으면
+// 그쪽으로, 없으면 --upstream 플래그로 지정된 기본 업스트림으로 보낸다.
+func (r *Replayer) send(ctx context.Context, payload RequestPayload) (upstreamResult, error) {
+	upstreamBase := r.UpstreamBase
+	if payload.Upstream != "" {
+		upstreamBase = payload.Upstream
+	}
+
+	req, err := http.NewRequestWithContext(ctx, payload.Method, strings.TrimRight(upstreamBase, "/")+payload.URI, bytes.NewReader([]byte(payload.Body)))
+	if err != nil {
+		return upstreamResult{}, err
+	}
+	for k, values := range payload.Headers {
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
+
+	resp, err := r.HTTPClient.Do(req)
+	if err != nil {
+		return upstreamResult{}, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return upstreamResult{}, err
+	}
+	result := upstreamResult{Status: resp.StatusCode, Headers: resp.Header, Body: string(body)}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return result, &upstreamError{status: resp.StatusCode}
+	}
+	return result, nil
+}
+
+// publishReply: 업스트림 응답을 ReplyPayload로 감싸 요청이 지정한 ReplyTopic에 발행
+func (r *Replayer) publishReply(ctx context.Context, payload RequestPayload, result upstreamResult) error {
+	reply := ReplyPayload{CorrelationID: payload.CorrelationID, Status: result.Status, Headers: result.Headers, Body: result.Body}
+	jsonData, err := json.Marshal(reply)
+	if err != nil {
+		return err
+	}
+
+	record := &kgo.Record{Topic: payload.ReplyTopic, Value: jsonData}
+	return r.KafkaClient.ProduceSync(ctx, record).FirstErr()
+}
+
+type upstreamError struct {
+	status int
+}
+
+func (e *upstreamError) Error() string {
+	return "upstream returned non-2xx status"
+}
+
+// publishToDLQ: 재시도를 모두 소진한 레코드를 실패 메타데이터와 함께 DLQ로 발행
+func (r *Replayer) publishToDLQ(ctx context.Context, payload RequestPayload, cause error) error {
+	reason := "unknown"
+	if cause != nil {
+		reason = cause.Error()
+	}
+
+	envelope := dlqEnvelope{Payload: payload, Reason: reason, Attempt: maxRetries}
+	jsonData, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+
+	record := &kgo.Record{Topic: dlqTopic, Value: jsonData}
+	if err := r.KafkaClient.ProduceSync(ctx, record).FirstErr(); err != nil {
+		return err
+	}
+	slog.Info("routed record to DLQ", slog.String("reason", reason))
+	return nil
+}
+
+// backoffWithJitter: 지수 백오프 + 지터 계산
+func backoffWithJitter(attempt int) time.Duration {
+	max := baseBackoff * time.Duration(1<<attempt)
+	jitter := time.Duration(rand.Int63n(int64(max)))
+	return max/2 + jitter/2
+}
+
+// handleDLQReplay: DLQ에 쌓인 레코드를 다시 foo 토픽으로 재주입하는 admin 엔드포인트
+func (r *Replayer) handleDLQReplay(c *gin.Context) {
+	var envelope dlqEnvelope
+	if err := c.ShouldBindJSON(&envelope); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid DLQ envelope"})
+		return
+	}
+
+	jsonData, err := json.Marshal(envelope.Payload)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to encode payload"})
+		return
+	}
+
+	record := &kgo.Record{Topic: sourceTopic, Value: jsonData}
+	if err := r.KafkaClient.ProduceSync(c.Request.Context(), record).FirstErr(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to re-inject record"})
+		slog.Error("failed to re-inject DLQ record", slog.String("error", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "record re-injected into foo"})
+}
+
+func main() {
+	kafkaAddrRaw := flag.String("kafka address", "localhost:9092", "kafka server address")
+	upstream := flag.String("upstream", "http://localhost:9000", "upstream base URL requests are replayed against")
+	adminAddr := flag.String("admin address", ":8081", "admin HTTP endpoint address")
+	flag.Parse()
+
+	kafkaAddr := strings.Split(*kafkaAddrRaw, ",")
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	slog.SetDefault(logger)
+
+	replayer, err := NewReplayer(kafkaAddr, *upstream)
+	if err != nil {
+		slog.Error("Failed to initialize replayer", slog.String("error", err.Error()))
+		return
+	}
+	slog.Info("Connected to kafka", slog.String("address", *kafkaAddrRaw), slog.String("upstream", *upstream))
+
+	go func() {
+		if err := replayer.Run(context.Background()); err != nil {
+			slog.Error("replayer loop stopped", slog.String("error", err.Error()))
+		}
+	}()
+
+	r := gin.Default()
+	r.POST("/dlq/replay", replayer.handleDLQReplay)
+
+	if err := r.Run(*adminAddr); err != nil {
+		slog.Error("Failed to start admin server", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,111 @@
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ElasticsearchSink: Elasticsearch/OpenSearch의 _bulk API로 레코드를 적재하는 Sink
+type ElasticsearchSink struct {
+	BaseURL       string
+	IndexTemplate string // 예: "phantomasync-{yyyy.MM.dd}"
+	Username      string
+	Password      string
+	APIKey        string
+	HTTPClient    *http.Client
+}
+
+// NewElasticsearchSink: TLS 설정을 포함한 HTTP 클라이언트로 ElasticsearchSink를 생성한다.
+func NewElasticsearchSink(baseURL, indexTemplate string, tlsConfig *tls.Config) *ElasticsearchSink {
+	return &ElasticsearchSink{
+		BaseURL:       strings.TrimRight(baseURL, "/"),
+		IndexTemplate: indexTemplate,
+		HTTPClient: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+	}
+}
+
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []struct {
+		Index struct {
+			Status int `json:"status"`
+		} `json:"index"`
+	} `json:"items"`
+}
+
+// Write: 레코드를 NDJSON bulk 요청으로 묶어 전송하고, 부분 실패 시 *PartialWriteError로 실패 인덱스를 알려준다.
+func (s *ElasticsearchSink) Write(ctx context.Context, records []Record) error {
+	var body bytes.Buffer
+	for _, record := range records {
+		index := resolveIndexName(s.IndexTemplate, record.Timestamp)
+		action := map[string]map[string]string{"index": {"_index": index}}
+		actionLine, err := json.Marshal(action)
+		if err != nil {
+			return err
+		}
+		body.Write(actionLine)
+		body.WriteByte('\n')
+		body.Write(record.Value)
+		body.WriteByte('\n')
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.BaseURL+"/_bulk", bytes.NewReader(body.Bytes()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	s.setAuth(req)
+
+	resp, err := s.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("elasticsearch bulk request failed with status %d", resp.StatusCode)
+	}
+
+	var parsed bulkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return err
+	}
+	if !parsed.Errors {
+		return nil
+	}
+
+	var failed []int
+	for i, item := range parsed.Items {
+		if item.Index.Status >= 300 {
+			failed = append(failed, i)
+		}
+	}
+	return &PartialWriteError{Failed: failed, Cause: fmt.Errorf("%d of %d bulk items failed", len(failed), len(records))}
+}
+
+func (s *ElasticsearchSink) setAuth(req *http.Request) {
+	if s.APIKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+s.APIKey)
+		return
+	}
+	if s.Username != "" {
+		req.SetBasicAuth(s.Username, s.Password)
+	}
+}
+
+// resolveIndexName: "{yyyy.MM.dd}" 플레이스홀더를 현재 레코드 타임스탬프 기준 날짜로 치환한다.
+func resolveIndexName(template string, ts time.Time) string {
+	if !strings.Contains(template, "{yyyy.MM.dd}") {
+		return template
+	}
+	return strings.ReplaceAll(template, "{yyyy.MM.dd}", ts.Format("2006.01.02"))
+}
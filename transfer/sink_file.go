@@ -0,0 +1,36 @@
+package transfer
+
+import (
+	"context"
+	"os"
+)
+
+// FileSink: 레코드를 줄 단위 JSON으로 파일에 append하는 Sink
+type FileSink struct {
+	Path string
+}
+
+// NewFileSink: FileSink 생성
+func NewFileSink(path string) *FileSink {
+	return &FileSink{Path: path}
+}
+
+func (s *FileSink) Write(_ context.Context, records []Record) error {
+	f, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	for _, record := range records {
+		// record.Value는 franz-go의 fetch 버퍼를 가리킬 수 있으므로, 그 뒤에 직접 append하지 않고
+		// 줄바꿈을 별도로 쓴다.
+		if _, err := f.Write(record.Value); err != nil {
+			return err
+		}
+		if _, err := f.Write([]byte{'\n'}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
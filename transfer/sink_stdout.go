@@ -0,0 +1,30 @@
+package transfer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+)
+
+// StdoutSink: 디버깅/로컬 실행용으로 레코드를 그대로 출력하는 Sink
+type StdoutSink struct {
+	Writer io.Writer
+}
+
+// NewStdoutSink: 호출자가 넘긴 Writer로 출력한다. w가 nil이면 os.Stdout을 사용한다.
+func NewStdoutSink(w io.Writer) *StdoutSink {
+	if w == nil {
+		w = os.Stdout
+	}
+	return &StdoutSink{Writer: w}
+}
+
+func (s *StdoutSink) Write(_ context.Context, records []Record) error {
+	for _, record := range records {
+		if _, err := fmt.Fprintf(s.Writer, "%s\n", record.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+// Package transfer는 Kafka를 버퍼로 사용해 레코드를 배치로 모았다가 Elasticsearch 등 저장소로 옮기는
+// "Transfer" 단계를 구현한다 (LogAgent -> Kafka -> Transfer -> ES 파이프라인의 마지막 단계).
+package transfer
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Record: Sink에 전달되는 한 건의 적재 대상 데이터
+type Record struct {
+	Value     []byte
+	Timestamp time.Time
+}
+
+// Sink: 배치로 모인 레코드를 실제 저장소에 쓰는 인터페이스
+type Sink interface {
+	Write(ctx context.Context, records []Record) error
+}
+
+// PartialWriteError: 배치 중 일부 레코드만 실패했을 때, 실패한 인덱스만 재시도할 수 있도록 돌려주는 에러
+type PartialWriteError struct {
+	Failed []int
+	Cause  error
+}
+
+func (e *PartialWriteError) Error() string {
+	return e.Cause.Error()
+}
+
+func (e *PartialWriteError) Unwrap() error {
+	return e.Cause
+}
+
+// Transfer: 컨슈머 그룹에 참여해 레코드를 배치로 모으고 Sink에 기록하는 러너
+type Transfer struct {
+	KafkaClient   *kgo.Client
+	Sink          Sink
+	BatchSize     int
+	FlushInterval time.Duration
+	MaxAttempts   int
+	DLQTopic      string
+}
+
+// NewTransfer: Transfer 러너 생성. 배치/플러시/재시도 파라미터에는 합리적인 기본값을 채워 넣는다.
+func NewTransfer(client *kgo.Client, sink Sink, dlqTopic string) *Transfer {
+	return &Transfer{
+		KafkaClient:   client,
+		Sink:          sink,
+		BatchSize:     500,
+		FlushInterval: 2 * time.Second,
+		MaxAttempts:   3,
+		DLQTopic:      dlqTopic,
+	}
+}
+
+// Run: FetchMessage로 레코드를 모아 크기/시간 임계치 중 먼저 도달하는 쪽에서 플러시한다.
+func (t *Transfer) Run(ctx context.Context) error {
+	batch := make([]*kgo.Record, 0, t.BatchSize)
+	ticker := time.NewTicker(t.FlushInterval)
+	defer ticker.Stop()
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ok := t.flush(ctx, batch)
+		batch = batch[:0]
+		if !ok {
+			// 배치 안에 sink 기록에도, DLQ 발행에도 실패한 레코드가 남아 있다. 그 레코드의 오프셋을
+			// 커밋하면 영영 사라지므로, 이번 배치는 커밋을 건너뛰고 다음 poll에서 재시도한다.
+			slog.Error("batch had records that were neither written nor routed to DLQ, skipping commit")
+			return
+		}
+		if err := t.KafkaClient.CommitUncommittedOffsets(ctx); err != nil {
+			slog.Error("failed to commit offsets", slog.String("error", err.Error()))
+		}
+	}
+
+	// PollFetches는 다음 레코드가 올 때까지 블록되므로, 별도 고루틴에서 돌려서 메인 select가
+	// 그동안에도 ticker.C를 제때 받아 FlushInterval을 지킬 수 있게 한다.
+	fetchesCh := make(chan kgo.Fetches)
+	pollErrCh := make(chan error, 1)
+	go func() {
+		for {
+			fetches := t.KafkaClient.PollFetches(ctx)
+			if err := ctx.Err(); err != nil {
+				pollErrCh <- err
+				return
+			}
+			select {
+			case fetchesCh <- fetches:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flush()
+			return ctx.Err()
+		case err := <-pollErrCh:
+			flush()
+			return err
+		case <-ticker.C:
+			flush()
+		case fetches := <-fetchesCh:
+			fetches.EachError(func(topic string, partition int32, err error) {
+				slog.Error("fetch error", slog.String("topic", topic), slog.Int("partition", int(partition)), slog.String("error", err.Error()))
+			})
+			fetches.EachRecord(func(record *kgo.Record) {
+				batch = append(batch, record)
+				if len(batch) >= t.BatchSize {
+					flush()
+				}
+			})
+		}
+	}
+}
+
+// flush: 배치를 Sink에 쓰고, 부분 실패한 레코드만 재시도한다. 재시도를 모두 소진하면 DLQ 토픽으로 보낸다.
+// 반환값이 false이면 sink 기록에도, DLQ 발행에도 실패한 레코드가 남아 있다는 뜻이므로, 호출자는 이 배치의
+// 오프셋을 커밋해서는 안 된다.
+func (t *Transfer) flush(ctx context.Context, batch []*kgo.Record) bool {
+	records := make([]Record, len(batch))
+	for i, kafkaRecord := range batch {
+		records[i] = Record{Value: kafkaRecord.Value, Timestamp: kafkaRecord.Timestamp}
+	}
+
+	pending := records
+	source := batch
+	for attempt := 1; attempt <= t.MaxAttempts && len(pending) > 0; attempt++ {
+		err := t.Sink.Write(ctx, pending)
+		if err == nil {
+			return true
+		}
+
+		var partial *PartialWriteError
+		if pe, ok := err.(*PartialWriteError); ok {
+			partial = pe
+		}
+		if partial == nil {
+			slog.Warn("sink write failed, retrying whole batch", slog.Int("attempt", attempt), slog.String("error", err.Error()))
+			continue
+		}
+
+		slog.Warn("sink write partially failed, retrying failed items", slog.Int("attempt", attempt), slog.Int("failed", len(partial.Failed)))
+		nextPending := make([]Record, 0, len(partial.Failed))
+		nextSource := make([]*kgo.Record, 0, len(partial.Failed))
+		for _, idx := range partial.Failed {
+			nextPending = append(nextPending, pending[idx])
+			nextSource = append(nextSource, source[idx])
+		}
+		pending, source = nextPending, nextSource
+	}
+
+	if len(pending) > 0 {
+		return t.routeToDLQ(ctx, source)
+	}
+	return true
+}
+
+// routeToDLQ: 재시도를 모두 소진한 레코드를 DLQ 토픽으로 보낸다. 반환값은 모든 레코드가 성공적으로
+// DLQ에 전달되었는지를 나타내며, 실패한 레코드가 하나라도 있으면 false다.
+func (t *Transfer) routeToDLQ(ctx context.Context, records []*kgo.Record) bool {
+	if t.DLQTopic == "" {
+		slog.Error("dropping records that exhausted retries, no DLQ topic configured", slog.Int("count", len(records)))
+		return false
+	}
+
+	ok := true
+	for _, record := range records {
+		envelope := map[string]json.RawMessage{"value": record.Value}
+		jsonData, err := json.Marshal(envelope)
+		if err != nil {
+			slog.Error("failed to encode DLQ envelope", slog.String("error", err.Error()))
+			ok = false
+			continue
+		}
+		dlqRecord := &kgo.Record{Topic: t.DLQTopic, Value: jsonData}
+		if err := t.KafkaClient.ProduceSync(ctx, dlqRecord).FirstErr(); err != nil {
+			slog.Error("failed to route record to transfer DLQ", slog.String("error", err.Error()))
+			ok = false
+		}
+	}
+	return ok
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"flag"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/computerphilosopher/phantomasync/transfer"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// newSink: --sink 플래그 값에 따라 transfer.Sink 구현체를 고른다.
+func newSink(kind, esURL, esIndexTemplate, esUsername, esPassword, esAPIKey, filePath string) (transfer.Sink, error) {
+	switch kind {
+	case "elasticsearch":
+		return transfer.NewElasticsearchSink(esURL, esIndexTemplate, &tls.Config{}), nil
+	case "file":
+		return transfer.NewFileSink(filePath), nil
+	case "stdout", "":
+		return transfer.NewStdoutSink(os.Stdout), nil
+	default:
+		return nil, &unknownSinkError{kind: kind}
+	}
+}
+
+type unknownSinkError struct {
+	kind string
+}
+
+func (e *unknownSinkError) Error() string {
+	return "unknown sink kind: " + e.kind
+}
+
+func main() {
+	kafkaAddrRaw := flag.String("kafka address", "localhost:9092", "kafka server address")
+	topic := flag.String("topic", "foo", "kafka topic to transfer from")
+	dlqTopic := flag.String("dlq topic", "foo.transfer.dlq", "kafka topic failed records are routed to after exhausting retries")
+	sinkKind := flag.String("sink", "stdout", "sink to write records to: elasticsearch, file, stdout")
+	esURL := flag.String("es url", "http://localhost:9200", "elasticsearch base URL")
+	esIndexTemplate := flag.String("es index template", "phantomasync-{yyyy.MM.dd}", "elasticsearch index name template")
+	esUsername := flag.String("es username", "", "elasticsearch basic auth username")
+	esPassword := flag.String("es password", "", "elasticsearch basic auth password")
+	esAPIKey := flag.String("es api key", "", "elasticsearch API key, takes precedence over basic auth")
+	filePath := flag.String("file path", "transfer-output.jsonl", "output file path when --sink=file")
+	flag.Parse()
+
+	kafkaAddr := strings.Split(*kafkaAddrRaw, ",")
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	slog.SetDefault(logger)
+
+	sink, err := newSink(*sinkKind, *esURL, *esIndexTemplate, *esUsername, *esPassword, *esAPIKey, *filePath)
+	if err != nil {
+		slog.Error("Failed to initialize sink", slog.String("error", err.Error()))
+		return
+	}
+	if es, ok := sink.(*transfer.ElasticsearchSink); ok {
+		es.Username = *esUsername
+		es.Password = *esPassword
+		es.APIKey = *esAPIKey
+	}
+
+	client, err := kgo.NewClient(
+		kgo.SeedBrokers(kafkaAddr...),
+		kgo.ConsumerGroup("my-group-identifier"),
+		kgo.ConsumeTopics(*topic),
+	)
+	if err != nil {
+		slog.Error("Failed to initialize kafka client", slog.String("error", err.Error()))
+		return
+	}
+	slog.Info("Connected to kafka", slog.String("address", *kafkaAddrRaw), slog.String("sink", *sinkKind))
+
+	runner := transfer.NewTransfer(client, sink, *dlqTopic)
+	if err := runner.Run(context.Background()); err != nil {
+		slog.Error("transfer runner stopped", slog.String("error", err.Error()))
+	}
+}